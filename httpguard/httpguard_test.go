@@ -0,0 +1,190 @@
+package httpguard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChainRejectsOnFirstFailingGuard(t *testing.T) {
+	var calledNext bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calledNext = true
+	})
+
+	handler := Chain(MethodAllowed(http.MethodPost))(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if calledNext {
+		t.Fatal("next handler was called despite a rejecting guard")
+	}
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestChainCallsNextWhenAllGuardsPass(t *testing.T) {
+	var calledNext bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calledNext = true
+	})
+
+	handler := Chain(MethodAllowed(http.MethodPost))(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !calledNext {
+		t.Fatal("next handler was not called despite all guards passing")
+	}
+}
+
+func TestMethodAllowed(t *testing.T) {
+	guard := MethodAllowed(http.MethodPost, http.MethodPut)
+
+	cases := []struct {
+		method  string
+		wantErr bool
+	}{
+		{http.MethodPost, false},
+		{http.MethodPut, false},
+		{http.MethodGet, true},
+		{http.MethodDelete, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/", nil)
+			_, err := guard(httptest.NewRecorder(), req)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("guard(%s) error = %v, wantErr %v", tc.method, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequireContentType(t *testing.T) {
+	guard := RequireContentType("application/json", "application/x-www-form-urlencoded")
+
+	cases := []struct {
+		name        string
+		contentType string
+		wantErr     bool
+	}{
+		{"json allowed", "application/json", false},
+		{"form allowed", "application/x-www-form-urlencoded", false},
+		{"json with charset param allowed", "application/json; charset=utf-8", false},
+		{"text plain rejected", "text/plain", true},
+		{"missing content type rejected", "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			_, err := guard(httptest.NewRecorder(), req)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("guard(%q) error = %v, wantErr %v", tc.contentType, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRejectSuspiciousHeaders(t *testing.T) {
+	guard := RejectSuspiciousHeaders()
+
+	t.Run("clean request passes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if _, err := guard(httptest.NewRecorder(), req); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("smuggling combination rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Content-Length", "10")
+		req.Header.Set("Transfer-Encoding", "chunked")
+		if _, err := guard(httptest.NewRecorder(), req); err == nil {
+			t.Error("expected an error for Content-Length + Transfer-Encoding")
+		}
+	})
+
+	t.Run("injected header value rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("X-Custom", "value\r\nX-Injected: true")
+		if _, err := guard(httptest.NewRecorder(), req); err == nil {
+			t.Error("expected an error for a header value containing CRLF")
+		}
+	})
+}
+
+func TestEnforceHTTPS(t *testing.T) {
+	guard := EnforceHTTPS()
+
+	t.Run("plain request rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if _, err := guard(httptest.NewRecorder(), req); err == nil {
+			t.Error("expected an error for a non-TLS request")
+		}
+	})
+
+	t.Run("forwarded proto https from an untrusted peer is rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.5:12345"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		if _, err := guard(httptest.NewRecorder(), req); err == nil {
+			t.Error("expected an error for X-Forwarded-Proto from a peer that isn't a trusted proxy")
+		}
+	})
+}
+
+func TestEnforceHTTPSTrustedProxy(t *testing.T) {
+	guard := EnforceHTTPS("10.0.0.0/8", "192.168.1.1")
+
+	t.Run("forwarded proto https from a trusted proxy passes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.1.2.3:54321"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		if _, err := guard(httptest.NewRecorder(), req); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("forwarded proto https from an untrusted peer is still rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.5:12345"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		if _, err := guard(httptest.NewRecorder(), req); err == nil {
+			t.Error("expected an error for X-Forwarded-Proto from a peer outside the trusted proxy list")
+		}
+	})
+
+	t.Run("trusted proxy without the header is still rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.168.1.1:8443"
+		if _, err := guard(httptest.NewRecorder(), req); err == nil {
+			t.Error("expected an error for a non-TLS request with no X-Forwarded-Proto")
+		}
+	})
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	guard := MaxBodyBytes(8)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789"))
+	rec := httptest.NewRecorder()
+	if _, err := guard(rec, req); err != nil {
+		t.Fatalf("guard returned an error: %v", err)
+	}
+
+	buf := make([]byte, 32)
+	n, readErr := req.Body.Read(buf)
+	if readErr == nil {
+		t.Fatalf("read %d bytes with no error, want an error once the 8-byte limit is exceeded", n)
+	}
+}
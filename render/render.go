@@ -0,0 +1,90 @@
+// Package render lets a single handler respond in text/html,
+// application/json, or text/plain from one data struct, chosen by the
+// request's Accept header (or a ?format= override). It replaces the
+// old pattern of hand-maintaining a parallel HTML and text/plain
+// template per handler: a Responder owns one of each, plus the JSON
+// encoder, behind a single Respond call.
+package render
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Format is one of the response formats a Responder can produce.
+type Format string
+
+const (
+	HTML Format = "html"
+	JSON Format = "json"
+	Text Format = "text"
+)
+
+// Responder renders the same data as HTML, JSON, or plain text.
+type Responder struct {
+	html *template.Template
+	text *texttemplate.Template
+}
+
+// NewResponder parses htmlSrc and textSrc once and returns a Responder
+// that can render either from then on.
+func NewResponder(htmlSrc, textSrc string) *Responder {
+	return &Responder{
+		html: template.Must(template.New("html").Parse(htmlSrc)),
+		text: texttemplate.Must(texttemplate.New("text").Parse(textSrc)),
+	}
+}
+
+// Respond picks a Format for r (via negotiate) and writes status and
+// data to w in that format. HTML is always rendered through
+// html/template, so user input is escaped unless the caller has
+// deliberately marked it as template.HTML.
+func (resp *Responder) Respond(w http.ResponseWriter, r *http.Request, data interface{}, status int) error {
+	switch negotiate(r) {
+	case JSON:
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		return json.NewEncoder(w).Encode(data)
+	case Text:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		return resp.text.Execute(w, data)
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		return resp.html.Execute(w, data)
+	}
+}
+
+// negotiate picks a Format for r: an explicit ?format= query parameter
+// wins, otherwise the Accept header is consulted, defaulting to HTML.
+func negotiate(r *http.Request) Format {
+	if f := r.URL.Query().Get("format"); f != "" {
+		switch strings.ToLower(f) {
+		case "json":
+			return JSON
+		case "text", "txt", "plain":
+			return Text
+		case "html":
+			return HTML
+		}
+	}
+
+	accept := r.Header.Get("Accept")
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		switch mediaType {
+		case "application/json":
+			return JSON
+		case "text/plain":
+			return Text
+		case "text/html", "*/*", "":
+			return HTML
+		}
+	}
+	return HTML
+}
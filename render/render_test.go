@@ -0,0 +1,103 @@
+package render
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testData struct {
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func newTestResponder() *Responder {
+	return NewResponder(
+		`{{if .Error}}<p class="error">{{.Error}}</p>{{end}}{{if .Output}}<p>{{.Output}}</p>{{end}}`,
+		`{{if .Error}}Error: {{.Error}}
+{{end}}{{if .Output}}Output: {{.Output}}
+{{end}}`,
+	)
+}
+
+func TestRespondHTMLDefault(t *testing.T) {
+	resp := newTestResponder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	if err := resp.Respond(rec, req, testData{Output: "hi"}, http.StatusOK); err != nil {
+		t.Fatalf("Respond returned error: %v", err)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html prefix", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "<p>hi</p>") {
+		t.Errorf("body = %q, want it to contain rendered HTML", rec.Body.String())
+	}
+}
+
+func TestRespondHTMLEscapesInput(t *testing.T) {
+	resp := newTestResponder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	if err := resp.Respond(rec, req, testData{Output: "<script>alert(1)</script>"}, http.StatusOK); err != nil {
+		t.Fatalf("Respond returned error: %v", err)
+	}
+	if strings.Contains(rec.Body.String(), "<script>") {
+		t.Errorf("body = %q, want HTML-escaped output, not raw <script>", rec.Body.String())
+	}
+}
+
+func TestRespondJSONViaAcceptHeader(t *testing.T) {
+	resp := newTestResponder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+
+	if err := resp.Respond(rec, req, testData{Output: "hi"}, http.StatusCreated); err != nil {
+		t.Fatalf("Respond returned error: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+	var got testData
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body is not valid JSON: %v", err)
+	}
+	if got.Output != "hi" {
+		t.Errorf("decoded Output = %q, want %q", got.Output, "hi")
+	}
+}
+
+func TestRespondFormatQueryOverridesAccept(t *testing.T) {
+	resp := newTestResponder()
+	req := httptest.NewRequest(http.MethodGet, "/?format=text", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+
+	if err := resp.Respond(rec, req, testData{Output: "hi"}, http.StatusOK); err != nil {
+		t.Fatalf("Respond returned error: %v", err)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain prefix", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "Output: hi") {
+		t.Errorf("body = %q, want plain text rendering", rec.Body.String())
+	}
+}
+
+func TestNegotiateDefaultsToHTML(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := negotiate(req); got != HTML {
+		t.Errorf("negotiate() = %v, want %v", got, HTML)
+	}
+}
@@ -0,0 +1,100 @@
+// Package upload validates and stores untrusted file uploads. Instead of
+// trusting the client's Content-Type header or the uploaded filename's
+// extension, it sniffs the real file type from the content itself and
+// writes the file under a fresh, randomly named subdirectory so two
+// uploads can never collide or overwrite one another.
+package upload
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// disallowedFilenameChar matches anything outside [A-Za-z0-9._-],
+// mirroring the filename allow-list used by go-sendxmpp's HTTP upload
+// code.
+var disallowedFilenameChar = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// SanitizeFilename strips any directory components from name and
+// replaces every character outside [A-Za-z0-9._-] with "_", so the
+// result is always safe to join onto a directory path. Backslashes are
+// treated as path separators before filepath.Base runs, since
+// filepath.Base only splits on the OS separator and would otherwise
+// leave a Windows-style traversal like "..\\..\\win.ini" intact on
+// Linux. A sanitized result of "." or ".." (the whole-component
+// traversal case filepath.Base can still hand back) is replaced with
+// "_", the same way validation.NoPathTraversal rejects it outright.
+func SanitizeFilename(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	base := filepath.Base(name)
+	base = disallowedFilenameChar.ReplaceAllString(base, "_")
+	if base == "." || base == ".." {
+		return "_"
+	}
+	return base
+}
+
+// DetectAndValidate sniffs the real MIME type of src from its content
+// (not the client-supplied Content-Type or filename) and checks it
+// against allowed. It rewinds src back to the start before returning so
+// the caller can still read the full upload afterward.
+func DetectAndValidate(src io.ReadSeeker, allowed []string) (*mimetype.MIME, error) {
+	mtype, err := mimetype.DetectReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("sniffing upload type: %w", err)
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("rewinding upload after sniffing: %w", err)
+	}
+	for _, a := range allowed {
+		if mtype.Is(a) {
+			return mtype, nil
+		}
+	}
+	return nil, fmt.Errorf("file type %q is not allowed", mtype.String())
+}
+
+// Save writes src to a new, randomly named subdirectory of baseDir using
+// the sanitized form of filename, and returns the path it wrote to. The
+// per-upload subdirectory means two uploads with the same filename never
+// collide and a crafted filename can't traverse outside baseDir.
+func Save(baseDir, filename string, src io.Reader) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(baseDir, token)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating upload directory: %w", err)
+	}
+
+	dst := filepath.Join(dir, SanitizeFilename(filename))
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("creating upload file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, src); err != nil {
+		return "", fmt.Errorf("writing upload file: %w", err)
+	}
+	return dst, nil
+}
+
+// randomToken returns a 32-character hex string used to name an
+// upload's subdirectory.
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating upload token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,105 @@
+package upload
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// pngMagicBytes are the first bytes of a real PNG file.
+var pngMagicBytes = []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A, 0, 0, 0, 0}
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"photo.png", "photo.png"},
+		{"../../etc/passwd", "passwd"},
+		{"my file (1).jpg", "my_file__1_.jpg"},
+		{"..\\..\\windows\\win.ini", "win.ini"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SanitizeFilename(tc.name); got != tc.want {
+				t.Errorf("SanitizeFilename(%q) = %q, want %q", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDetectAndValidateAcceptsRealMagicBytes(t *testing.T) {
+	src := bytes.NewReader(pngMagicBytes)
+	mtype, err := DetectAndValidate(src, []string{"image/png"})
+	if err != nil {
+		t.Fatalf("DetectAndValidate returned error for a real PNG: %v", err)
+	}
+	if !mtype.Is("image/png") {
+		t.Errorf("detected type = %q, want image/png", mtype.String())
+	}
+}
+
+func TestDetectAndValidateRejectsForgedExtension(t *testing.T) {
+	// A plain text payload, pretending (via its filename/extension, not
+	// tested here) to be a PNG. The sniffed content type must win.
+	src := bytes.NewReader([]byte("<html><script>alert(1)</script></html>"))
+	if _, err := DetectAndValidate(src, []string{"image/png", "image/jpeg"}); err == nil {
+		t.Fatal("expected an error for content that is not actually an allowed image type")
+	}
+}
+
+func TestDetectAndValidateRewindsAfterSniffing(t *testing.T) {
+	src := bytes.NewReader(pngMagicBytes)
+	if _, err := DetectAndValidate(src, []string{"image/png"}); err != nil {
+		t.Fatalf("DetectAndValidate returned error: %v", err)
+	}
+	rest := make([]byte, len(pngMagicBytes))
+	n, err := src.Read(rest)
+	if err != nil {
+		t.Fatalf("reading after DetectAndValidate: %v", err)
+	}
+	if n != len(pngMagicBytes) || !bytes.Equal(rest, pngMagicBytes) {
+		t.Errorf("read %d bytes %v after DetectAndValidate, want the full original content", n, rest)
+	}
+}
+
+func TestSaveWritesUnderAFreshSubdirectory(t *testing.T) {
+	baseDir := t.TempDir()
+
+	path1, err := Save(baseDir, "report.pdf", bytes.NewReader([]byte("pdf-a")))
+	if err != nil {
+		t.Fatalf("first Save returned error: %v", err)
+	}
+	path2, err := Save(baseDir, "report.pdf", bytes.NewReader([]byte("pdf-b")))
+	if err != nil {
+		t.Fatalf("second Save returned error: %v", err)
+	}
+
+	if path1 == path2 {
+		t.Fatalf("two uploads with the same filename collided at %q", path1)
+	}
+	if filepath.Dir(path1) == filepath.Dir(path2) {
+		t.Fatalf("uploads were not stored in distinct subdirectories: %q and %q", path1, path2)
+	}
+	for _, p := range []string{path1, path2} {
+		if filepath.Dir(filepath.Dir(p)) != baseDir {
+			t.Errorf("path %q escaped base directory %q", p, baseDir)
+		}
+	}
+}
+
+func TestSaveSanitizesTraversalAttempt(t *testing.T) {
+	baseDir := t.TempDir()
+
+	path, err := Save(baseDir, "../../etc/passwd", bytes.NewReader([]byte("x")))
+	if err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if filepath.Base(path) != "passwd" {
+		t.Errorf("stored filename = %q, want traversal segments stripped to %q", filepath.Base(path), "passwd")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected file to exist at %q: %v", path, err)
+	}
+}
@@ -0,0 +1,313 @@
+// Package validation provides a small, composable rule engine for
+// validating and sanitizing untrusted input. Handlers declare a Validator
+// per field instead of hard-coding ad-hoc checks, so new policies can be
+// added without touching handler code.
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Rule checks (and optionally transforms) a field value. Rules that only
+// validate should return the value unchanged; rules that normalize
+// (NFCNormalize) return the transformed value for downstream rules to see.
+type Rule interface {
+	Apply(value string) (string, error)
+}
+
+// named is implemented by rules that want a human-readable name to show
+// up in a ValidationError instead of their Go type name.
+type named interface {
+	Name() string
+}
+
+type ruleFunc struct {
+	name string
+	fn   func(string) (string, error)
+}
+
+func (r ruleFunc) Apply(value string) (string, error) { return r.fn(value) }
+func (r ruleFunc) Name() string                       { return r.name }
+
+func ruleName(r Rule) string {
+	if n, ok := r.(named); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", r)
+}
+
+// ValidationError reports which field and rule rejected an input. Safe
+// holds a redacted, log-safe rendering of the offending value; Unsafe
+// holds the raw value and must never be written to a log or response.
+type ValidationError struct {
+	Field   string
+	Rule    string
+	Message string
+	Safe    string
+	Unsafe  string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// redact produces a log-safe rendering of a value: control characters are
+// escaped and the result is truncated so a single bad field can't flood
+// logs.
+func redact(value string) string {
+	const maxLen = 64
+	var b strings.Builder
+	for i, r := range value {
+		if i >= maxLen {
+			b.WriteString("...")
+			break
+		}
+		if unicode.IsControl(r) {
+			fmt.Fprintf(&b, "\\u%04x", r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Validator runs a sequence of Rules against a single named field.
+type Validator struct {
+	Field string
+	Rules []Rule
+}
+
+// New builds a Validator for field that applies rules in order, stopping
+// at the first failure.
+func New(field string, rules ...Rule) *Validator {
+	return &Validator{Field: field, Rules: rules}
+}
+
+// Validate runs v's rules over input in order, threading the (possibly
+// normalized) value through each rule. It returns the final value or a
+// *ValidationError describing the first rule that rejected it.
+func (v *Validator) Validate(input string) (string, error) {
+	value := input
+	for _, rule := range v.Rules {
+		out, err := rule.Apply(value)
+		if err != nil {
+			return "", &ValidationError{
+				Field:   v.Field,
+				Rule:    ruleName(rule),
+				Message: err.Error(),
+				Safe:    redact(input),
+				Unsafe:  input,
+			}
+		}
+		value = out
+	}
+	return value, nil
+}
+
+// Compose combines rules into a single Rule, applying each in order. It
+// lets a commonly reused policy be registered under one name.
+func Compose(rules ...Rule) Rule {
+	return ruleFunc{
+		name: "Compose",
+		fn: func(value string) (string, error) {
+			for _, rule := range rules {
+				out, err := rule.Apply(value)
+				if err != nil {
+					return "", err
+				}
+				value = out
+			}
+			return value, nil
+		},
+	}
+}
+
+// MinLen rejects values shorter than n runes.
+func MinLen(n int) Rule {
+	return ruleFunc{
+		name: "MinLen",
+		fn: func(value string) (string, error) {
+			if len([]rune(value)) < n {
+				return "", fmt.Errorf("must be at least %d characters", n)
+			}
+			return value, nil
+		},
+	}
+}
+
+// MaxLen rejects values longer than n runes.
+func MaxLen(n int) Rule {
+	return ruleFunc{
+		name: "MaxLen",
+		fn: func(value string) (string, error) {
+			if len([]rune(value)) > n {
+				return "", fmt.Errorf("exceeds maximum length of %d characters", n)
+			}
+			return value, nil
+		},
+	}
+}
+
+// Charset rejects any value containing a rune not in set.
+func Charset(set string) Rule {
+	allowed := make(map[rune]bool, len(set))
+	for _, r := range set {
+		allowed[r] = true
+	}
+	return ruleFunc{
+		name: "Charset",
+		fn: func(value string) (string, error) {
+			for _, r := range value {
+				if !allowed[r] {
+					return "", fmt.Errorf("contains disallowed character %q", r)
+				}
+			}
+			return value, nil
+		},
+	}
+}
+
+// Regexp rejects any value that does not match pat in its entirety.
+func Regexp(pat string) Rule {
+	re := regexp.MustCompile(`^(?:` + pat + `)$`)
+	return ruleFunc{
+		name: "Regexp",
+		fn: func(value string) (string, error) {
+			if !re.MatchString(value) {
+				return "", fmt.Errorf("does not match required pattern %q", pat)
+			}
+			return value, nil
+		},
+	}
+}
+
+// NoControlChars rejects values containing ASCII or Unicode control
+// characters (other than the empty string itself).
+func NoControlChars() Rule {
+	return ruleFunc{
+		name: "NoControlChars",
+		fn: func(value string) (string, error) {
+			for _, r := range value {
+				if unicode.IsControl(r) {
+					return "", fmt.Errorf("contains a control character")
+				}
+			}
+			return value, nil
+		},
+	}
+}
+
+// NFCNormalize rewrites value to Unicode Normalization Form C so that
+// later rules (Charset, NoConfusables, Regexp) see a canonical form
+// instead of one of several visually identical byte sequences.
+func NFCNormalize() Rule {
+	return ruleFunc{
+		name: "NFCNormalize",
+		fn: func(value string) (string, error) {
+			return norm.NFC.String(value), nil
+		},
+	}
+}
+
+// confusables maps characters that are commonly used to impersonate an
+// ASCII letter or digit (homoglyph attacks) to the letter they mimic.
+// This is a small, hand-picked subset of the Unicode confusables table,
+// not the full data file.
+var confusables = map[rune]rune{
+	'а': 'a', // CYRILLIC SMALL LETTER A
+	'е': 'e', // CYRILLIC SMALL LETTER IE
+	'о': 'o', // CYRILLIC SMALL LETTER O
+	'р': 'p', // CYRILLIC SMALL LETTER ER
+	'с': 'c', // CYRILLIC SMALL LETTER ES
+	'у': 'y', // CYRILLIC SMALL LETTER U
+	'х': 'x', // CYRILLIC SMALL LETTER HA
+	'і': 'i', // CYRILLIC SMALL LETTER BYELORUSSIAN-UKRAINIAN I
+	'ο': 'o', // GREEK SMALL LETTER OMICRON
+	'Α': 'A', // GREEK CAPITAL LETTER ALPHA
+	'Ε': 'E', // GREEK CAPITAL LETTER EPSILON
+	'Ι': 'I', // GREEK CAPITAL LETTER IOTA
+	'Ο': 'O', // GREEK CAPITAL LETTER OMICRON
+	'Ρ': 'P', // GREEK CAPITAL LETTER RHO
+}
+
+// NoConfusables rejects values containing a character from the
+// confusables table, preventing visual spoofing of ASCII input.
+func NoConfusables() Rule {
+	return ruleFunc{
+		name: "NoConfusables",
+		fn: func(value string) (string, error) {
+			for _, r := range value {
+				if target, ok := confusables[r]; ok {
+					return "", fmt.Errorf("contains %q, a lookalike of %q", r, target)
+				}
+			}
+			return value, nil
+		},
+	}
+}
+
+// sqlMeta are characters and sequences commonly used in SQL injection
+// payloads.
+var sqlMeta = []string{"'", "\"", ";", "--", "/*", "*/"}
+
+// NoSQLMeta rejects values containing common SQL metacharacters. It is a
+// defense-in-depth check and is not a substitute for parameterized
+// queries.
+func NoSQLMeta() Rule {
+	return ruleFunc{
+		name: "NoSQLMeta",
+		fn: func(value string) (string, error) {
+			for _, meta := range sqlMeta {
+				if strings.Contains(value, meta) {
+					return "", fmt.Errorf("contains disallowed sequence %q", meta)
+				}
+			}
+			return value, nil
+		},
+	}
+}
+
+// shellMeta are characters commonly used to chain or substitute commands
+// when input reaches a shell.
+const shellMeta = ";|&$`\\<>(){}\n"
+
+// NoShellMeta rejects values containing shell metacharacters. It is a
+// defense-in-depth check and is not a substitute for avoiding shell
+// invocation of untrusted input entirely.
+func NoShellMeta() Rule {
+	return ruleFunc{
+		name: "NoShellMeta",
+		fn: func(value string) (string, error) {
+			if i := strings.IndexAny(value, shellMeta); i != -1 {
+				return "", fmt.Errorf("contains shell metacharacter %q", value[i])
+			}
+			return value, nil
+		},
+	}
+}
+
+// NoPathTraversal rejects values that could escape a base directory when
+// joined onto a path: parent-directory references, absolute paths, and
+// backslashes.
+func NoPathTraversal() Rule {
+	return ruleFunc{
+		name: "NoPathTraversal",
+		fn: func(value string) (string, error) {
+			if strings.Contains(value, "..") {
+				return "", fmt.Errorf("contains parent directory reference %q", "..")
+			}
+			if strings.ContainsAny(value, "\\") {
+				return "", fmt.Errorf("contains disallowed character %q", '\\')
+			}
+			if strings.HasPrefix(value, "/") {
+				return "", fmt.Errorf("must not be an absolute path")
+			}
+			return value, nil
+		},
+	}
+}
@@ -0,0 +1,244 @@
+package validation
+
+import "testing"
+
+func TestMinLen(t *testing.T) {
+	rule := MinLen(3)
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"too short", "ab", true},
+		{"exact", "abc", false},
+		{"longer", "abcd", false},
+		{"unicode runes counted", "αβγ", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaxLen(t *testing.T) {
+	rule := MaxLen(5)
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"within limit", "abcde", false},
+		{"over limit", "abcdef", true},
+		{"empty", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCharset(t *testing.T) {
+	rule := Charset("abcABC123")
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"all allowed", "aA1", false},
+		{"disallowed char", "aAz", true},
+		{"empty", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegexp(t *testing.T) {
+	rule := Regexp(`^[0-9]{3}-[0-9]{4}$`)
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"matches", "123-4567", false},
+		{"no match", "1234567", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNoControlChars(t *testing.T) {
+	rule := NoControlChars()
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"clean", "hello world", false},
+		{"newline", "hello\nworld", true},
+		{"null byte", "hello\x00world", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNFCNormalize(t *testing.T) {
+	// "e" + combining acute accent (NFD) should normalize to "é" (NFC).
+	decomposed := "é"
+	got, err := NFCNormalize().Apply(decomposed)
+	if err != nil {
+		t.Fatalf("Apply(%q) returned error: %v", decomposed, err)
+	}
+	if want := "é"; got != want {
+		t.Fatalf("Apply(%q) = %q, want %q", decomposed, got, want)
+	}
+}
+
+func TestNoConfusables(t *testing.T) {
+	rule := NoConfusables()
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"plain ascii", "admin", false},
+		{"cyrillic lookalike a", "аdmin", true}, // leading char is Cyrillic а
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNoSQLMeta(t *testing.T) {
+	rule := NoSQLMeta()
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"clean", "smith", false},
+		{"single quote", "o'brien", true},
+		{"comment sequence", "1--drop", true},
+		{"block comment", "a/*b*/c", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNoShellMeta(t *testing.T) {
+	rule := NoShellMeta()
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"clean", "file.txt", false},
+		{"semicolon", "file.txt; rm -rf /", true},
+		{"pipe", "file.txt | cat", true},
+		{"backtick", "`whoami`", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNoPathTraversal(t *testing.T) {
+	rule := NoPathTraversal()
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"clean relative", "uploads/file.txt", false},
+		{"parent reference", "../../etc/passwd", true},
+		{"absolute path", "/etc/passwd", true},
+		{"backslash", "uploads\\file.txt", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompose(t *testing.T) {
+	rule := Compose(MinLen(1), MaxLen(10), Charset("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"))
+	cases := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"valid", "Passw0rd", false},
+		{"empty fails MinLen", "", true},
+		{"too long fails MaxLen", "thisvalueiswaytoolong", true},
+		{"disallowed char fails Charset", "pass word", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rule.Apply(tc.input); (err != nil) != tc.wantErr {
+				t.Fatalf("Apply(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorValidate(t *testing.T) {
+	v := New("username", MinLen(3), MaxLen(16), NoControlChars())
+
+	if _, err := v.Validate("alice"); err != nil {
+		t.Fatalf("Validate(%q) returned error: %v", "alice", err)
+	}
+
+	_, err := v.Validate("ab")
+	if err == nil {
+		t.Fatal("Validate(\"ab\") = nil error, want a ValidationError")
+	}
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("error type = %T, want *ValidationError", err)
+	}
+	if verr.Field != "username" {
+		t.Errorf("Field = %q, want %q", verr.Field, "username")
+	}
+	if verr.Rule != "MinLen" {
+		t.Errorf("Rule = %q, want %q", verr.Rule, "MinLen")
+	}
+	if verr.Unsafe != "ab" {
+		t.Errorf("Unsafe = %q, want %q", verr.Unsafe, "ab")
+	}
+}